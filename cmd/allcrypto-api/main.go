@@ -0,0 +1,151 @@
+// Command allcrypto-api serves the user-management HTTP API and
+// exposes a small `tokens` subcommand for provisioning API tokens.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/cors"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/auth"
+	"github.com/Sharkprojects/allcrypto-api/pkg/config"
+	"github.com/Sharkprojects/allcrypto-api/pkg/controllers"
+	"github.com/Sharkprojects/allcrypto-api/pkg/idempotency"
+	"github.com/Sharkprojects/allcrypto-api/pkg/logging"
+	"github.com/Sharkprojects/allcrypto-api/pkg/metrics"
+	"github.com/Sharkprojects/allcrypto-api/pkg/migrate"
+	"github.com/Sharkprojects/allcrypto-api/pkg/store"
+)
+
+//go:embed index.html
+var content embed.FS
+
+func main() {
+	configPath := flag.String("config", "", "caminho para o arquivo de configuração TOML")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+
+	db, err := store.Open(cfg.Database())
+	if err != nil {
+		log.Fatalf("Erro fatal ao conectar ao banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	logger := logging.New()
+	logger.Info("conexão com o banco de dados bem-sucedida")
+
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			runMigrateCommand(db, args[1:])
+			return
+		case "tokens":
+			runTokensCommand(db, args[1:])
+			return
+		}
+	}
+
+	if _, err := migrate.Up(context.Background(), db); err != nil {
+		log.Fatalf("Erro ao aplicar migrações: %v", err)
+	}
+
+	userStore := store.NewPostgresUserStore(db)
+	idempotencyStore := idempotency.NewStore(db)
+	idempotencyStore.StartSweeper(context.Background(), 10*time.Minute)
+	metrics.CollectDBStats(context.Background(), db, 15*time.Second)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(content)))
+	mux.Handle("/api/usuarios", auth.RequireRole(db, auth.RoleReadonly)(logging.Middleware(logger, "/api/usuarios")(controllers.ListarUsuariosHandler(userStore))))
+	mux.Handle("/api/user-action", auth.RequireRole(db, auth.RoleReadonly)(logging.Middleware(logger, "/api/user-action")(idempotencyStore.Middleware(controllers.UserActionsHandler(userStore)))))
+	mux.Handle("/api/audit", auth.RequireRole(db, auth.RoleReadonly)(logging.Middleware(logger, "/api/audit")(controllers.AuditHandler(userStore))))
+	mux.Handle("/api/audit/export.csv", auth.RequireRole(db, auth.RoleReadonly)(logging.Middleware(logger, "/api/audit/export.csv")(controllers.AuditExportCSVHandler(userStore))))
+	mux.Handle("/metrics", auth.RequireRole(db, auth.RoleReadonly)(metrics.Handler()))
+
+	handler := cors.New(cors.Options{
+		AllowedOrigins: cfg.CORS(),
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	}).Handler(mux)
+
+	logger.Info("iniciando servidor", "addr", cfg.Listen(), "cors", true)
+	if err := http.ListenAndServe(cfg.Listen(), handler); err != nil {
+		log.Fatalf("Erro ao iniciar o servidor: %v", err)
+	}
+}
+
+// runMigrateCommand implements `allcrypto-api migrate up|down|status`.
+func runMigrateCommand(db *sql.DB, args []string) {
+	ctx := context.Background()
+	if len(args) == 0 {
+		log.Fatal("uso: allcrypto-api migrate up|down|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrate.Up(ctx, db)
+		if err != nil {
+			log.Fatalf("Erro ao aplicar migrações: %v", err)
+		}
+		fmt.Printf("%d migração(ões) aplicada(s): %v\n", len(applied), applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				log.Fatalf("número de passos inválido: %v", err)
+			}
+			steps = n
+		}
+		reverted, err := migrate.Down(ctx, db, steps)
+		if err != nil {
+			log.Fatalf("Erro ao reverter migrações: %v", err)
+		}
+		fmt.Printf("%d migração(ões) revertida(s): %v\n", len(reverted), reverted)
+	case "status":
+		statuses, err := migrate.ListStatus(ctx, db)
+		if err != nil {
+			log.Fatalf("Erro ao consultar status das migrações: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pendente"
+			if s.Applied {
+				state = "aplicada"
+			}
+			fmt.Printf("%04d_%s: %s\n", s.Version, s.Name, state)
+		}
+	default:
+		log.Fatal("uso: allcrypto-api migrate up|down|status")
+	}
+}
+
+// runTokensCommand implements the `allcrypto-api tokens <subcommand>`
+// CLI, currently just `create --role=admin|operator|readonly`.
+func runTokensCommand(db *sql.DB, args []string) {
+	if len(args) == 0 || args[0] != "create" {
+		log.Fatal("uso: allcrypto-api tokens create --role=admin|operator|readonly")
+	}
+
+	fs := flag.NewFlagSet("tokens create", flag.ExitOnError)
+	role := fs.String("role", "", "papel do token (admin, operator ou readonly)")
+	fs.Parse(args[1:])
+
+	token, err := auth.CreateToken(db, auth.Role(*role))
+	if err != nil {
+		log.Fatalf("Erro ao criar token: %v", err)
+	}
+	fmt.Println("Token criado (anote agora, não será exibido novamente):")
+	fmt.Println(token)
+}
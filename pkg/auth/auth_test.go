@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRoleSatisfies(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleOperator, true},
+		{RoleAdmin, RoleReadonly, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleOperator, RoleOperator, true},
+		{RoleOperator, RoleReadonly, true},
+		{RoleReadonly, RoleOperator, false},
+		{RoleReadonly, RoleReadonly, true},
+		{Role("desconhecido"), RoleReadonly, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Satisfies(tt.required); got != tt.want {
+			t.Errorf("Role(%q).Satisfies(%q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestFromContextMissing(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("esperava ok=false para um contexto sem AuthContext")
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxFailedAttempts = 5
+	failedAttemptsTTL = time.Minute
+)
+
+// failureLimiter blunts brute-forcing of the Authorization header by
+// locking out a source IP after too many failed auth attempts in a
+// short window. It is intentionally in-memory: a restart clears it,
+// which is an acceptable tradeoff for this API's traffic.
+type failureLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+func newFailureLimiter() *failureLimiter {
+	return &failureLimiter{failures: make(map[string][]time.Time)}
+}
+
+func (l *failureLimiter) blocked(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(ip)
+	return len(l.failures[ip]) >= maxFailedAttempts
+}
+
+func (l *failureLimiter) recordFailure(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(ip)
+	l.failures[ip] = append(l.failures[ip], time.Now())
+}
+
+// prune must be called with l.mu held.
+func (l *failureLimiter) prune(ip string) {
+	cutoff := time.Now().Add(-failedAttemptsTTL)
+	kept := l.failures[ip][:0]
+	for _, t := range l.failures[ip] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		delete(l.failures, ip)
+		return
+	}
+	l.failures[ip] = kept
+}
+
+var limiter = newFailureLimiter()
+
+// SourceIP returns the caller's IP, stripped of the port net/http
+// leaves on RemoteAddr. Exported so other packages (e.g. controllers,
+// for the audit trail's actor IP) don't need their own copy.
+func SourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, prefix)
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(`{"message":"` + message + `"}`))
+}
+
+// RequireRole wraps an http.Handler so that only requests bearing a
+// valid, non-revoked token whose role satisfies the given role are
+// allowed through. The resulting AuthContext is injected into the
+// request context for downstream handlers via FromContext.
+func RequireRole(db *sql.DB, role Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := SourceIP(r)
+			if limiter.blocked(ip) {
+				writeUnauthorized(w, "Muitas tentativas de autenticação falhas, tente novamente mais tarde")
+				return
+			}
+
+			ac, err := Authenticate(db, bearerToken(r))
+			if err != nil {
+				limiter.recordFailure(ip)
+				writeUnauthorized(w, "Token inválido ou ausente")
+				return
+			}
+			if !ac.Role.Satisfies(role) {
+				writeUnauthorized(w, "Permissão insuficiente para esta ação")
+				return
+			}
+
+			r = r.WithContext(NewContext(r.Context(), ac))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailureLimiterLocksOutAfterMaxAttempts(t *testing.T) {
+	l := newFailureLimiter()
+	ip := "203.0.113.9"
+
+	for i := 0; i < maxFailedAttempts; i++ {
+		if l.blocked(ip) {
+			t.Fatalf("tentativa %d: não deveria estar bloqueado ainda", i)
+		}
+		l.recordFailure(ip)
+	}
+	if !l.blocked(ip) {
+		t.Fatal("esperava bloqueio após atingir maxFailedAttempts falhas")
+	}
+	if l.blocked("203.0.113.10") {
+		t.Fatal("o bloqueio não deveria afetar um IP diferente")
+	}
+}
+
+func requireRoleRequest(t *testing.T, db *sql.DB, role Role, remoteAddr, authHeader string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := RequireRole(db, role)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireRoleRejectsMissingToken(t *testing.T) {
+	db := newTestDB(t)
+	w := requireRoleRequest(t, db, RoleReadonly, "198.51.100.1:1234", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("esperava 401 sem token, got %d", w.Code)
+	}
+}
+
+func TestRequireRoleRejectsInvalidToken(t *testing.T) {
+	db := newTestDB(t)
+	w := requireRoleRequest(t, db, RoleReadonly, "198.51.100.2:1234", "Bearer aca_nao-existe")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("esperava 401 com token inválido, got %d", w.Code)
+	}
+}
+
+func TestRequireRoleEnforcesRoleHierarchy(t *testing.T) {
+	db := newTestDB(t)
+	token, err := CreateToken(db, RoleReadonly)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	w := requireRoleRequest(t, db, RoleAdmin, "198.51.100.3:1234", "Bearer "+token)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("esperava 401 quando o papel do token não satisfaz o exigido, got %d", w.Code)
+	}
+
+	w = requireRoleRequest(t, db, RoleReadonly, "198.51.100.4:1234", "Bearer "+token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("esperava 200 quando o papel do token satisfaz o exigido, got %d", w.Code)
+	}
+}
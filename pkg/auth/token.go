@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidToken is returned when a presented bearer token does not
+// match any non-revoked row in api_tokens.
+var ErrInvalidToken = errors.New("auth: invalid or revoked token")
+
+// HashToken returns the hex-encoded SHA-512 digest of a raw token, the
+// form stored in api_tokens.token_hash.
+func HashToken(token string) string {
+	sum := sha512.Sum512([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateToken returns a random 32-byte token hex-encoded for
+// transport, prefixed so leaked tokens are greppable in logs.
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("auth: gerar token: %v", err)
+	}
+	return "aca_" + hex.EncodeToString(buf), nil
+}
+
+// CreateToken generates a new token, stores its hash with the given
+// role, and returns the raw token. The raw value is never persisted and
+// is only returned here so the caller can print it once.
+func CreateToken(db *sql.DB, role Role) (string, error) {
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("auth: role desconhecida: %q", role)
+	}
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = db.Exec(
+		`INSERT INTO api_tokens (token_hash, role, created_at) VALUES ($1, $2, now())`,
+		HashToken(token), string(role),
+	)
+	if err != nil {
+		return "", fmt.Errorf("auth: gravar token: %v", err)
+	}
+	return token, nil
+}
+
+// Authenticate looks up a raw bearer token by its hash, rejecting
+// revoked tokens, and updates last_used on success.
+func Authenticate(db *sql.DB, token string) (*AuthContext, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	var ac AuthContext
+	var role string
+	err := db.QueryRow(
+		`SELECT id, role FROM api_tokens WHERE token_hash = $1 AND revoked_at IS NULL`,
+		HashToken(token),
+	).Scan(&ac.TokenID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: consultar token: %v", err)
+	}
+	ac.Role = Role(role)
+
+	if _, err := db.Exec(`UPDATE api_tokens SET last_used = now() WHERE id = $1`, ac.TokenID); err != nil {
+		return nil, fmt.Errorf("auth: atualizar last_used: %v", err)
+	}
+	return &ac, nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("SKIP_DOCKER_TESTS") != "" {
+		t.Skip("SKIP_DOCKER_TESTS set, skipping testcontainers-backed test")
+	}
+
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "allcrypto_test",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Skipf("não foi possível iniciar container Postgres (Docker indisponível?): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("container.Host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("container.MappedPort: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/allcrypto_test?sslmode=disable", host, port.Port())
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("db.PingContext: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE api_tokens (
+			id bigserial PRIMARY KEY,
+			token_hash text NOT NULL UNIQUE,
+			role text NOT NULL,
+			created_at timestamptz NOT NULL DEFAULT now(),
+			last_used timestamptz,
+			revoked_at timestamptz
+		)
+	`); err != nil {
+		t.Fatalf("criar tabela api_tokens: %v", err)
+	}
+	return db
+}
+
+func TestHashTokenIsDeterministicAndDistinct(t *testing.T) {
+	if HashToken("abc") != HashToken("abc") {
+		t.Fatal("HashToken deveria ser determinístico para a mesma entrada")
+	}
+	if HashToken("abc") == HashToken("abd") {
+		t.Fatal("HashToken não deveria colidir para entradas diferentes")
+	}
+}
+
+func TestCreateTokenRejectsUnknownRole(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := CreateToken(db, Role("superusuario")); err == nil {
+		t.Fatal("esperava erro ao criar token com role desconhecida")
+	}
+}
+
+func TestCreateTokenAndAuthenticateRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+
+	token, err := CreateToken(db, RoleOperator)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	ac, err := Authenticate(db, token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if ac.Role != RoleOperator {
+		t.Fatalf("esperava role %q, got %q", RoleOperator, ac.Role)
+	}
+	if ac.TokenID == 0 {
+		t.Fatal("esperava TokenID diferente de zero")
+	}
+
+	var lastUsed sql.NullTime
+	if err := db.QueryRow(`SELECT last_used FROM api_tokens WHERE id = $1`, ac.TokenID).Scan(&lastUsed); err != nil {
+		t.Fatalf("consultar last_used: %v", err)
+	}
+	if !lastUsed.Valid {
+		t.Fatal("esperava last_used preenchido após Authenticate")
+	}
+}
+
+func TestAuthenticateRejectsInvalidToken(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, err := Authenticate(db, ""); err != ErrInvalidToken {
+		t.Fatalf("esperava ErrInvalidToken para token vazio, got %v", err)
+	}
+	if _, err := Authenticate(db, "aca_nao-existe"); err != ErrInvalidToken {
+		t.Fatalf("esperava ErrInvalidToken para token desconhecido, got %v", err)
+	}
+}
+
+func TestAuthenticateRejectsRevokedToken(t *testing.T) {
+	db := newTestDB(t)
+
+	token, err := CreateToken(db, RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE api_tokens SET revoked_at = now() WHERE token_hash = $1`, HashToken(token)); err != nil {
+		t.Fatalf("revogar token: %v", err)
+	}
+
+	if _, err := Authenticate(db, token); err != ErrInvalidToken {
+		t.Fatalf("esperava ErrInvalidToken para token revogado, got %v", err)
+	}
+}
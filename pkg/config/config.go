@@ -0,0 +1,83 @@
+// Package config loads allcrypto-api's runtime configuration from a
+// TOML file, overlaid with environment variables so deployments can
+// override individual settings without editing the file in place.
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the parsed contents of the TOML config file.
+type Config struct {
+	DB      databaseConfig `toml:"database"`
+	Server  listenConfig   `toml:"listen"`
+	Cors    corsConfig     `toml:"cors"`
+	Session sessionConfig  `toml:"session"`
+}
+
+type databaseConfig struct {
+	URL string `toml:"url"`
+}
+
+type listenConfig struct {
+	Port string `toml:"port"`
+}
+
+type corsConfig struct {
+	AllowedOrigins []string `toml:"allowed_origins"`
+}
+
+type sessionConfig struct {
+	Secret string `toml:"secret"`
+}
+
+// Load reads the TOML file at path, if any, and overlays it with
+// environment variables. path may be empty, in which case the config
+// is built from environment variables alone.
+func Load(path string) (*Config, error) {
+	var cfg Config
+	if path != "" {
+		if _, err := toml.DecodeFile(path, &cfg); err != nil {
+			return nil, fmt.Errorf("config: carregar %s: %v", path, err)
+		}
+	}
+
+	if v := os.Getenv("DATABASE_URL"); v != "" {
+		cfg.DB.URL = v
+	}
+	if v := os.Getenv("PORT"); v != "" {
+		cfg.Server.Port = v
+	}
+	if v := os.Getenv("SESSION_SECRET"); v != "" {
+		cfg.Session.Secret = v
+	}
+
+	if cfg.Server.Port == "" {
+		cfg.Server.Port = "8080"
+	}
+	if len(cfg.Cors.AllowedOrigins) == 0 {
+		cfg.Cors.AllowedOrigins = []string{"*"}
+	}
+
+	if cfg.DB.URL == "" {
+		return nil, fmt.Errorf("config: database.url não definido (arquivo de config ou DATABASE_URL)")
+	}
+
+	return &cfg, nil
+}
+
+// Database returns the Postgres connection string.
+func (c *Config) Database() string { return c.DB.URL }
+
+// Listen returns the "host:port"-style address to bind, e.g. ":8080".
+func (c *Config) Listen() string { return ":" + c.Server.Port }
+
+// CORS returns the set of origins allowed to call the API.
+func (c *Config) CORS() []string { return c.Cors.AllowedOrigins }
+
+// SessionStore returns the secret used to sign future session-based
+// auth artifacts (not yet consumed by any handler).
+func (c *Config) SessionStore() string { return c.Session.Secret }
@@ -0,0 +1,235 @@
+// Package controllers holds the HTTP handlers for allcrypto-api,
+// talking to Postgres only through the pkg/store interfaces.
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/auth"
+	"github.com/Sharkprojects/allcrypto-api/pkg/logging"
+	"github.com/Sharkprojects/allcrypto-api/pkg/metrics"
+	"github.com/Sharkprojects/allcrypto-api/pkg/store"
+)
+
+// Response is the envelope returned by every handler in this package.
+type Response struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// auditFilterFromQuery builds a store.AuditFilter from the
+// username/action/since/limit query parameters shared by
+// AuditHandler and AuditExportCSVHandler.
+func auditFilterFromQuery(r *http.Request) (store.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := store.AuditFilter{
+		Username: q.Get("username"),
+		Action:   q.Get("action"),
+	}
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = t
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return filter, err
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
+
+// ListarUsuariosHandler returns every user in the system.
+func ListarUsuariosHandler(s store.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			jsonResponse(w, http.StatusMethodNotAllowed, Response{Message: "Método não permitido"})
+			return
+		}
+		users, err := s.List(r.Context())
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, Response{Message: "Erro ao buscar usuários: " + err.Error()})
+			return
+		}
+		jsonResponse(w, http.StatusOK, Response{Message: "Usuários listados com sucesso", Data: users})
+	}
+}
+
+// actionRoles maps a UserActionsHandler action to the minimum role
+// required to perform it. Actions absent from this map (there are none
+// today) fall through to the "Ação desconhecida" branch below.
+var actionRoles = map[string]auth.Role{
+	"inserirUsuario":     auth.RoleAdmin,
+	"atualizarSenha":     auth.RoleAdmin,
+	"bloquearUsuario":    auth.RoleAdmin,
+	"atualizarIP":        auth.RoleOperator,
+	"atualizarRenovacao": auth.RoleOperator,
+	"atualizarIndicacao": auth.RoleOperator,
+	"resetarSenha":       auth.RoleAdmin,
+}
+
+// UserActionsHandler dispatches the mutating "action" carried in the
+// request body. Every action here requires at least the role recorded
+// in actionRoles, enforced against the auth.AuthContext the auth
+// middleware injected into the request.
+func UserActionsHandler(s store.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			jsonResponse(w, http.StatusMethodNotAllowed, Response{Message: "Método não permitido"})
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			jsonResponse(w, http.StatusBadRequest, Response{Message: "Requisição inválida"})
+			return
+		}
+		action, _ := payload["action"].(string)
+		username, _ := payload["username"].(string)
+		logging.SetAction(r.Context(), action)
+
+		ac, ok := auth.FromContext(r.Context())
+		if !ok {
+			jsonResponse(w, http.StatusUnauthorized, Response{Message: "Requisição não autenticada"})
+			return
+		}
+		if required, ok := actionRoles[action]; ok && !ac.Role.Satisfies(required) {
+			metrics.RecordUserAction(action, "forbidden")
+			jsonResponse(w, http.StatusForbidden, Response{Message: "Permissão insuficiente para a ação '" + action + "'"})
+			return
+		}
+
+		ctx := r.Context()
+		actor := store.Actor{TokenID: ac.TokenID, IP: auth.SourceIP(r)}
+		var err error
+
+		switch action {
+		case "inserirUsuario":
+			password, _ := payload["password"].(string)
+			renewalDate, _ := payload["renewal_date"].(string)
+			err = s.Insert(ctx, actor, payload, username, password, renewalDate)
+		case "atualizarSenha":
+			newPassword, _ := payload["new_password"].(string)
+			err = s.UpdatePassword(ctx, actor, payload, username, newPassword)
+		case "bloquearUsuario":
+			isBlocked, _ := payload["is_blocked"].(bool)
+			err = s.SetBlocked(ctx, actor, payload, username, isBlocked)
+		case "atualizarRenovacao":
+			renewalDate, _ := payload["renewal_date"].(string)
+			err = s.UpdateRenewalDate(ctx, actor, payload, username, renewalDate)
+		case "atualizarIndicacao":
+			indicacao, ok := payload["indicacao"].(float64)
+			if !ok {
+				jsonResponse(w, http.StatusBadRequest, Response{Message: "Valor de indicação inválido"})
+				return
+			}
+			err = s.UpdateIndicacao(ctx, actor, payload, username, int(indicacao))
+		case "atualizarIP":
+			novoIP, _ := payload["novo_ip"].(string)
+			err = s.UpdateIP(ctx, actor, payload, username, novoIP)
+		case "resetarSenha":
+			err = s.ResetPassword(ctx, actor, payload, username)
+		default:
+			metrics.RecordUserAction(action, "desconhecida")
+			jsonResponse(w, http.StatusBadRequest, Response{Message: "Ação desconhecida"})
+			return
+		}
+
+		if errors.Is(err, store.ErrNotFound) {
+			metrics.RecordUserAction(action, "nao_encontrado")
+			jsonResponse(w, http.StatusNotFound, Response{Message: "Nenhum usuário encontrado com o nome fornecido."})
+			return
+		}
+		if err != nil {
+			metrics.RecordUserAction(action, "erro")
+			jsonResponse(w, http.StatusInternalServerError, Response{Message: "Erro ao executar ação '" + action + "': " + err.Error()})
+			return
+		}
+		metrics.RecordUserAction(action, "sucesso")
+		jsonResponse(w, http.StatusOK, Response{Message: "Ação '" + action + "' executada com sucesso!"})
+	}
+}
+
+// AuditHandler returns a paginated, filterable view of audit_log as
+// JSON. Supported query params: username, action, since (RFC3339),
+// limit.
+func AuditHandler(s store.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			jsonResponse(w, http.StatusMethodNotAllowed, Response{Message: "Método não permitido"})
+			return
+		}
+		filter, err := auditFilterFromQuery(r)
+		if err != nil {
+			jsonResponse(w, http.StatusBadRequest, Response{Message: "Parâmetros de filtro inválidos: " + err.Error()})
+			return
+		}
+		entries, err := s.ListAudit(r.Context(), filter)
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, Response{Message: "Erro ao buscar audit log: " + err.Error()})
+			return
+		}
+		jsonResponse(w, http.StatusOK, Response{Message: "Audit log listado com sucesso", Data: entries})
+	}
+}
+
+// AuditExportCSVHandler streams the same filtered audit_log view as
+// AuditHandler, encoded as CSV.
+func AuditExportCSVHandler(s store.UserStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			jsonResponse(w, http.StatusMethodNotAllowed, Response{Message: "Método não permitido"})
+			return
+		}
+		filter, err := auditFilterFromQuery(r)
+		if err != nil {
+			jsonResponse(w, http.StatusBadRequest, Response{Message: "Parâmetros de filtro inválidos: " + err.Error()})
+			return
+		}
+		entries, err := s.ListAudit(r.Context(), filter)
+		if err != nil {
+			jsonResponse(w, http.StatusInternalServerError, Response{Message: "Erro ao buscar audit log: " + err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write([]string{"id", "at", "actor_token_id", "actor_ip", "action", "target_username", "payload", "old_values", "new_values"})
+		for _, e := range entries {
+			var actorTokenID, actorIP string
+			if e.ActorTokenID != nil {
+				actorTokenID = strconv.FormatInt(*e.ActorTokenID, 10)
+			}
+			if e.ActorIP != nil {
+				actorIP = *e.ActorIP
+			}
+			csvWriter.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.At.Format(time.RFC3339),
+				actorTokenID,
+				actorIP,
+				e.Action,
+				e.TargetUsername,
+				string(e.Payload),
+				string(e.OldValues),
+				string(e.NewValues),
+			})
+		}
+		csvWriter.Flush()
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/auth"
+	"github.com/Sharkprojects/allcrypto-api/pkg/store"
+)
+
+// fakeUserStore is a minimal in-memory store.UserStore, so handler
+// tests don't need a real Postgres instance.
+type fakeUserStore struct {
+	insertCalls []insertCall
+	insertErr   error
+}
+
+type insertCall struct {
+	actor       store.Actor
+	username    string
+	password    string
+	renewalDate string
+}
+
+func (f *fakeUserStore) List(ctx context.Context) ([]store.User, error) { return nil, nil }
+
+func (f *fakeUserStore) Insert(ctx context.Context, actor store.Actor, payload map[string]interface{}, username, password, renewalDate string) error {
+	f.insertCalls = append(f.insertCalls, insertCall{actor, username, password, renewalDate})
+	return f.insertErr
+}
+
+func (f *fakeUserStore) UpdatePassword(ctx context.Context, actor store.Actor, payload map[string]interface{}, username, newPassword string) error {
+	return nil
+}
+
+func (f *fakeUserStore) SetBlocked(ctx context.Context, actor store.Actor, payload map[string]interface{}, username string, blocked bool) error {
+	return nil
+}
+
+func (f *fakeUserStore) UpdateRenewalDate(ctx context.Context, actor store.Actor, payload map[string]interface{}, username, renewalDate string) error {
+	return nil
+}
+
+func (f *fakeUserStore) UpdateIndicacao(ctx context.Context, actor store.Actor, payload map[string]interface{}, username string, indicacao int) error {
+	return nil
+}
+
+func (f *fakeUserStore) UpdateIP(ctx context.Context, actor store.Actor, payload map[string]interface{}, username, ip string) error {
+	return nil
+}
+
+func (f *fakeUserStore) ResetPassword(ctx context.Context, actor store.Actor, payload map[string]interface{}, username string) error {
+	return nil
+}
+
+func (f *fakeUserStore) VerifyPassword(ctx context.Context, username, password string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeUserStore) ListAudit(ctx context.Context, filter store.AuditFilter) ([]store.AuditEntry, error) {
+	return nil, nil
+}
+
+func postUserAction(t *testing.T, s store.UserStore, ac *auth.AuthContext, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+	if ac != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), ac))
+	}
+	w := httptest.NewRecorder()
+	UserActionsHandler(s)(w, req)
+	return w
+}
+
+// TestUserActionsHandlerRejectsMissingAuthContext guards against the
+// nil-pointer panic this handler shipped with for several commits: it
+// dereferenced auth.FromContext's AuthContext without checking ok.
+func TestUserActionsHandlerRejectsMissingAuthContext(t *testing.T) {
+	fake := &fakeUserStore{}
+	w := postUserAction(t, fake, nil, `{"action":"inserirUsuario","username":"alice"}`)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("esperava 401 sem AuthContext, got %d", w.Code)
+	}
+	if len(fake.insertCalls) != 0 {
+		t.Fatal("o store não deveria ter sido chamado sem AuthContext")
+	}
+}
+
+func TestUserActionsHandlerRejectsInsufficientRole(t *testing.T) {
+	fake := &fakeUserStore{}
+	ac := &auth.AuthContext{TokenID: 1, Role: auth.RoleOperator}
+
+	w := postUserAction(t, fake, ac, `{"action":"inserirUsuario","username":"alice"}`)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("esperava 403 com papel insuficiente, got %d", w.Code)
+	}
+	if len(fake.insertCalls) != 0 {
+		t.Fatal("o store não deveria ter sido chamado com papel insuficiente")
+	}
+}
+
+func TestUserActionsHandlerDispatchesHappyPath(t *testing.T) {
+	fake := &fakeUserStore{}
+	ac := &auth.AuthContext{TokenID: 42, Role: auth.RoleAdmin}
+
+	w := postUserAction(t, fake, ac, `{"action":"inserirUsuario","username":"alice","password":"s3nha","renewal_date":"2026-01-01"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("esperava 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.insertCalls) != 1 {
+		t.Fatalf("esperava Insert chamado 1 vez, chamado %d vez(es)", len(fake.insertCalls))
+	}
+	call := fake.insertCalls[0]
+	if call.username != "alice" || call.password != "s3nha" || call.renewalDate != "2026-01-01" {
+		t.Fatalf("Insert chamado com argumentos inesperados: %+v", call)
+	}
+	if call.actor.TokenID != 42 {
+		t.Fatalf("esperava actor.TokenID 42, got %d", call.actor.TokenID)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decodificar resposta: %v", err)
+	}
+	if resp.Message == "" {
+		t.Fatal("esperava mensagem de sucesso não vazia")
+	}
+}
@@ -0,0 +1,231 @@
+// Package idempotency lets a POST handler be safely retried: a client
+// that sends the same Idempotency-Key header twice gets the first
+// response replayed instead of the mutation running again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TTL is how long a recorded response is replayed before the sweeper
+// considers it expired.
+const TTL = 24 * time.Hour
+
+// Store records request/response pairs keyed by Idempotency-Key in
+// Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore wraps an already-connected *sql.DB.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// record is a row read back from idempotency_keys. pending is true for
+// a key that has been claimed (see claim) but whose response hasn't
+// been recorded yet, i.e. the original request is still in flight.
+type record struct {
+	requestHash    string
+	pending        bool
+	responseStatus int
+	responseBody   []byte
+}
+
+func hashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) get(ctx context.Context, key string) (*record, error) {
+	var r record
+	var status sql.NullInt64
+	var body []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT request_hash, response_status, response_body FROM idempotency_keys WHERE key = $1 AND created_at > now() - ($2 * interval '1 second')`,
+		key, TTL.Seconds(),
+	).Scan(&r.requestHash, &status, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !status.Valid {
+		r.pending = true
+	} else {
+		r.responseStatus = int(status.Int64)
+		r.responseBody = body
+	}
+	return &r, nil
+}
+
+// claim atomically inserts a pending row for key, so that of any
+// number of concurrent requests sharing the same Idempotency-Key, only
+// one ever runs next. claimed is true for the caller that won the
+// insert and must now run the handler and call complete; existing
+// holds the row to act on (replay or reject) for every other caller.
+func (s *Store) claim(ctx context.Context, key, requestHash string) (claimed bool, existing *record, err error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (key, request_hash) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`,
+		key, requestHash,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, nil, err
+	}
+	if n == 1 {
+		return true, nil, nil
+	}
+	existing, err = s.get(ctx, key)
+	return false, existing, err
+}
+
+func (s *Store) complete(ctx context.Context, key string, status int, body []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET response_status = $2, response_body = $3 WHERE key = $1`,
+		key, status, body,
+	)
+	return err
+}
+
+// release deletes a claimed-but-never-completed row, so a handler
+// panic doesn't strand the key in "pending" for the rest of its TTL.
+func (s *Store) release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return err
+}
+
+// responseRecorder captures the status and body a handler writes while
+// still passing them through to the real client.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// Middleware makes next idempotent for requests carrying an
+// Idempotency-Key header (a UUID). Requests without the header pass
+// through unchanged. A repeat key with the same request body replays
+// the first response; a repeat key with a different body is rejected
+// with 409 Conflict. The key is claimed atomically before next runs,
+// so two requests racing on the same key never both execute it: the
+// loser is rejected with 409 instead of running next a second time. If
+// next panics, the claim is released before the panic is re-raised, so
+// the key doesn't stay stuck as "in progress" for the rest of its TTL.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, err := uuid.Parse(key); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Idempotency-Key deve ser um UUID")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Não foi possível ler o corpo da requisição")
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := hashRequest(body)
+
+		claimed, existing, err := s.claim(r.Context(), key, requestHash)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Erro ao registrar chave de idempotência: "+err.Error())
+			return
+		}
+		if !claimed {
+			switch {
+			case existing == nil:
+				// The claim lost the race but the row is gone by the
+				// time we read it back (e.g. swept as expired). Safe
+				// to tell the client to retry with a fresh key.
+				writeJSONError(w, http.StatusConflict, "Não foi possível registrar a Idempotency-Key, tente novamente")
+			case existing.requestHash != requestHash:
+				writeJSONError(w, http.StatusConflict, "Idempotency-Key já foi usada com um corpo de requisição diferente")
+			case existing.pending:
+				writeJSONError(w, http.StatusConflict, "Uma requisição com esta Idempotency-Key já está em andamento")
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(existing.responseStatus)
+				w.Write(existing.responseBody)
+			}
+			return
+		}
+
+		defer func() {
+			if rp := recover(); rp != nil {
+				if err := s.release(r.Context(), key); err != nil {
+					log.Printf("idempotency: erro ao liberar chave %s após panic no handler: %v", key, err)
+				}
+				panic(rp)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if err := s.complete(r.Context(), key, rec.status, rec.body.Bytes()); err != nil {
+			log.Printf("idempotency: erro ao gravar resposta para chave %s: %v", key, err)
+		}
+	})
+}
+
+// StartSweeper runs a background goroutine that deletes expired
+// idempotency_keys rows every interval, until ctx is cancelled.
+func (s *Store) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := s.db.ExecContext(ctx,
+					`DELETE FROM idempotency_keys WHERE created_at <= now() - ($1 * interval '1 second')`,
+					TTL.Seconds(),
+				)
+				if err != nil {
+					log.Printf("idempotency: erro ao limpar chaves expiradas: %v", err)
+				}
+			}
+		}
+	}()
+}
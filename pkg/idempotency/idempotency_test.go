@@ -0,0 +1,256 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("SKIP_DOCKER_TESTS") != "" {
+		t.Skip("SKIP_DOCKER_TESTS set, skipping testcontainers-backed test")
+	}
+
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "allcrypto_test",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Skipf("não foi possível iniciar container Postgres (Docker indisponível?): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("container.Host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("container.MappedPort: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/allcrypto_test?sslmode=disable", host, port.Port())
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("db.PingContext: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE idempotency_keys (
+			key text PRIMARY KEY,
+			request_hash text NOT NULL,
+			response_status integer,
+			response_body bytea,
+			created_at timestamptz NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		t.Fatalf("criar tabela idempotency_keys: %v", err)
+	}
+	return db
+}
+
+func TestMiddlewareReplaysSameBody(t *testing.T) {
+	db := newTestDB(t)
+	store := NewStore(db)
+
+	calls := 0
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"message":"criado"}`))
+	}))
+
+	key := uuid.New().String()
+	body := `{"action":"inserirUsuario","username":"alice"}`
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("tentativa %d: esperava 201, got %d", i, w.Code)
+		}
+		if w.Body.String() != `{"message":"criado"}` {
+			t.Fatalf("tentativa %d: corpo inesperado: %s", i, w.Body.String())
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("esperava o handler interno rodar 1 vez, rodou %d", calls)
+	}
+}
+
+func TestMiddlewareRejectsDifferentBodySameKey(t *testing.T) {
+	db := newTestDB(t)
+	store := NewStore(db)
+
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	key := uuid.New().String()
+
+	req1 := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(`{"username":"alice"}`))
+	req1.Header.Set("Idempotency-Key", key)
+	w1 := httptest.NewRecorder()
+	handler.ServeHTTP(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("primeira requisição: esperava 200, got %d", w1.Code)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(`{"username":"bob"}`))
+	req2.Header.Set("Idempotency-Key", key)
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, req2)
+	if w2.Code != http.StatusConflict {
+		t.Fatalf("segunda requisição com corpo diferente: esperava 409, got %d", w2.Code)
+	}
+}
+
+func TestMiddlewareRejectsConcurrentDuplicateRequest(t *testing.T) {
+	db := newTestDB(t)
+	store := NewStore(db)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"message":"criado"}`))
+	}))
+
+	key := uuid.New().String()
+	body := `{"action":"inserirUsuario","username":"alice"}`
+
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		first <- w
+	}()
+
+	<-started // the first request has claimed the key and is mid-flight
+
+	req2 := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+	req2.Header.Set("Idempotency-Key", key)
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, req2)
+
+	close(release)
+	w1 := <-first
+
+	if w2.Code != http.StatusConflict {
+		t.Fatalf("requisição concorrente: esperava 409 (chave em andamento), got %d: %s", w2.Code, w2.Body.String())
+	}
+	if w1.Code != http.StatusCreated {
+		t.Fatalf("requisição original: esperava 201, got %d", w1.Code)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("esperava o handler interno rodar 1 vez, rodou %d", calls)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	db := newTestDB(t)
+	store := NewStore(db)
+
+	var calls int32
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		panic("falha simulada no handler")
+	}))
+
+	key := uuid.New().String()
+	body := `{"action":"inserirUsuario","username":"alice"}`
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("esperava que o panic do handler se propagasse")
+			}
+		}()
+		req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM idempotency_keys WHERE key = $1`, key).Scan(&count); err != nil {
+		t.Fatalf("consultar idempotency_keys: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("esperava que a chave fosse liberada após o panic, ainda há %d linha(s)", count)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+
+	retryHandler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	retryHandler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("retentativa após panic: esperava 201, got %d", w.Code)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("esperava o handler interno rodar 2 vezes (falha + retentativa), rodou %d", calls)
+	}
+}
+
+func TestMiddlewareRejectsNonUUIDKey(t *testing.T) {
+	db := newTestDB(t)
+	store := NewStore(db)
+
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("handler interno não deveria ser chamado")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user-action", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "not-a-uuid")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("esperava 400 para chave inválida, got %d", w.Code)
+	}
+}
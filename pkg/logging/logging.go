@@ -0,0 +1,26 @@
+// Package logging provides allcrypto-api's structured access log: a
+// slog.Logger emitting one JSON object per line, and an HTTP
+// middleware that assigns a request ID, times the handler, and feeds
+// pkg/metrics.
+package logging
+
+import (
+	"log/slog"
+	"os"
+)
+
+// New returns a logger that writes JSON lines to stdout, with the
+// default time field renamed to "ts" to match the access log's field
+// set (ts, level, msg, req_id, method, path, status, dur_ms, user,
+// action).
+func New() *slog.Logger {
+	opts := &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				a.Key = "ts"
+			}
+			return a
+		},
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/auth"
+	"github.com/Sharkprojects/allcrypto-api/pkg/metrics"
+)
+
+// RequestIDHeader is the header Middleware reads an inbound request ID
+// from, and echoes it on the response either way.
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey int
+
+const stateKey ctxKey = 0
+
+// requestState is stashed in the request context as a pointer so a
+// handler further down the chain can annotate the access log line
+// Middleware writes once the handler returns.
+type requestState struct {
+	action string
+}
+
+// SetAction records the action name (e.g. the key UserActionsHandler
+// dispatched on) for the access log line of the in-flight request.
+// It is a no-op if the request did not pass through Middleware.
+func SetAction(ctx context.Context, action string) {
+	if s, ok := ctx.Value(stateKey).(*requestState); ok {
+		s.action = action
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware assigns a request ID (reusing an inbound X-Request-ID if
+// present), times next, and writes one JSON access log line to logger
+// with fields ts, level, msg, req_id, method, path, status, dur_ms,
+// user and action. It also feeds path/method/status/duration into
+// pkg/metrics. path should be the route pattern registered with the
+// mux, not the raw request path, so metric label cardinality stays
+// bounded.
+//
+// Middleware must sit inside auth.RequireRole in the handler chain so
+// that auth.FromContext(r.Context()) already resolves to the caller's
+// token when the access log line is written.
+func Middleware(logger *slog.Logger, path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqID := r.Header.Get(RequestIDHeader)
+			if reqID == "" {
+				reqID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, reqID)
+
+			state := &requestState{}
+			r = r.WithContext(context.WithValue(r.Context(), stateKey, state))
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(rec, r)
+			dur := time.Since(start)
+
+			var user string
+			if ac, ok := auth.FromContext(r.Context()); ok {
+				user = strconv.FormatInt(ac.TokenID, 10)
+			}
+
+			metrics.ObserveHTTPRequest(path, r.Method, rec.status, dur)
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http_request",
+				slog.String("req_id", reqID),
+				slog.String("method", r.Method),
+				slog.String("path", path),
+				slog.Int("status", rec.status),
+				slog.Float64("dur_ms", float64(dur.Microseconds())/1000),
+				slog.String("user", user),
+				slog.String("action", state.action),
+			)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package metrics holds allcrypto-api's Prometheus instrumentation:
+// HTTP request counters/latency, per-action counters for
+// UserActionsHandler, and a gauge tracking open database connections.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total de requisições HTTP atendidas, por rota, método e status.",
+	}, []string{"path", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duração das requisições HTTP, por rota e método.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"path", "method"})
+
+	userActionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_actions_total",
+		Help: "Total de ações processadas por UserActionsHandler, por ação e resultado.",
+	}, []string{"action", "result"})
+
+	dbOpenConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "db_open_connections",
+		Help: "Conexões abertas com o banco de dados, de sql.DB.Stats().OpenConnections.",
+	})
+)
+
+// ObserveHTTPRequest records one sample of http_requests_total and
+// http_request_duration_seconds. path should be the route pattern
+// registered with the mux, not the raw request path, to keep label
+// cardinality bounded.
+func ObserveHTTPRequest(path, method string, status int, dur time.Duration) {
+	httpRequestsTotal.WithLabelValues(path, method, strconv.Itoa(status)).Inc()
+	httpRequestDuration.WithLabelValues(path, method).Observe(dur.Seconds())
+}
+
+// RecordUserAction increments user_actions_total for a single action
+// dispatched by UserActionsHandler.
+func RecordUserAction(action, result string) {
+	userActionsTotal.WithLabelValues(action, result).Inc()
+}
+
+// CollectDBStats starts a background goroutine that samples
+// db.Stats().OpenConnections into db_open_connections every interval,
+// until ctx is cancelled.
+func CollectDBStats(ctx context.Context, db *sql.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			dbOpenConnections.Set(float64(db.Stats().OpenConnections))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Handler exposes the registered metrics for scraping.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
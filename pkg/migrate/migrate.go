@@ -0,0 +1,239 @@
+// Package migrate applies versioned SQL migrations embedded from
+// migrations/, tracking which ones have run in a schema_migrations
+// table so repeated startups are idempotent.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one versioned step, with its forward and (optional)
+// reverse SQL.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// Status describes one migration's applied state, for the `status`
+// subcommand.
+type Status struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: ler diretório migrations: %v", err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		version, label, err := parseFilename(name)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: ler %s: %v", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: label}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(contents)
+		} else {
+			m.down = string(contents)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// parseFilename splits "0001_init.up.sql" into (1, "init").
+func parseFilename(name string) (int, string, error) {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, ".up.sql"), ".down.sql")
+	parts := strings.SplitN(base, "_", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("migrate: nome de arquivo inválido: %s", name)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("migrate: versão inválida em %s: %v", name, err)
+	}
+	return version, parts[1], nil
+}
+
+func ensureSchemaMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version integer PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("migrate: preparar schema_migrations: %v", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: consultar schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("migrate: ler versão aplicada: %v", err)
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+// Up runs every migration not yet recorded in schema_migrations, in
+// version order, each inside its own transaction. It returns the
+// versions it applied.
+func Up(ctx context.Context, db *sql.DB) ([]int, error) {
+	if err := ensureSchemaMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []int
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := runInTx(ctx, db, m.up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name)
+			return err
+		}); err != nil {
+			return ran, fmt.Errorf("migrate: aplicar %04d_%s: %v", m.version, m.name, err)
+		}
+		ran = append(ran, m.version)
+	}
+	return ran, nil
+}
+
+// Down reverts the most recently applied `steps` migrations, in
+// reverse version order.
+func Down(ctx context.Context, db *sql.DB, steps int) ([]int, error) {
+	if err := ensureSchemaMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version > migrations[j].version })
+
+	var reverted []int
+	for _, m := range migrations {
+		if steps <= 0 {
+			break
+		}
+		if !applied[m.version] {
+			continue
+		}
+		if m.down == "" {
+			return reverted, fmt.Errorf("migrate: sem SQL de reversão para %04d_%s", m.version, m.name)
+		}
+		if err := runInTx(ctx, db, m.down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.version)
+			return err
+		}); err != nil {
+			return reverted, fmt.Errorf("migrate: reverter %04d_%s: %v", m.version, m.name, err)
+		}
+		reverted = append(reverted, m.version)
+		steps--
+	}
+	return reverted, nil
+}
+
+// ListStatus reports, for every known migration, whether it has been
+// applied.
+func ListStatus(ctx context.Context, db *sql.DB) ([]Status, error) {
+	if err := ensureSchemaMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		statuses = append(statuses, Status{Version: m.version, Name: m.name, Applied: applied[m.version]})
+	}
+	return statuses, nil
+}
+
+func runInTx(ctx context.Context, db *sql.DB, sqlText string, after func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqlText); err != nil {
+		return err
+	}
+	if err := after(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
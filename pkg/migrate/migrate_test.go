@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("SKIP_DOCKER_TESTS") != "" {
+		t.Skip("SKIP_DOCKER_TESTS set, skipping testcontainers-backed test")
+	}
+
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "allcrypto_test",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Skipf("não foi possível iniciar container Postgres (Docker indisponível?): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("container.Host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("container.MappedPort: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/allcrypto_test?sslmode=disable", host, port.Port())
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("db.PingContext: %v", err)
+	}
+	return db
+}
+
+func TestUpDownStatus(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	statuses, err := ListStatus(ctx, db)
+	if err != nil {
+		t.Fatalf("ListStatus: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Fatalf("esperava nenhuma migração aplicada antes de Up, got %+v", s)
+		}
+	}
+
+	applied, err := Up(ctx, db)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(statuses) {
+		t.Fatalf("esperava aplicar %d migrações, aplicou %d", len(statuses), len(applied))
+	}
+
+	// Running Up again should be a no-op.
+	appliedAgain, err := Up(ctx, db)
+	if err != nil {
+		t.Fatalf("Up (segunda vez): %v", err)
+	}
+	if len(appliedAgain) != 0 {
+		t.Fatalf("esperava 0 migrações na segunda execução, aplicou %d", len(appliedAgain))
+	}
+
+	var tableCount int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM information_schema.tables WHERE table_name IN ('users', 'api_tokens')`).Scan(&tableCount); err != nil {
+		t.Fatalf("consultar tabelas: %v", err)
+	}
+	if tableCount != 2 {
+		t.Fatalf("esperava tabelas users e api_tokens criadas, got count=%d", tableCount)
+	}
+
+	reverted, err := Down(ctx, db, len(applied))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != len(applied) {
+		t.Fatalf("esperava reverter %d migrações, reverteu %d", len(applied), len(reverted))
+	}
+
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM information_schema.tables WHERE table_name IN ('users', 'api_tokens')`).Scan(&tableCount); err != nil {
+		t.Fatalf("consultar tabelas após Down: %v", err)
+	}
+	if tableCount != 0 {
+		t.Fatalf("esperava tabelas removidas após Down, got count=%d", tableCount)
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuditEntry is one row of the audit_log table.
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	At             time.Time       `json:"at"`
+	ActorTokenID   *int64          `json:"actor_token_id"`
+	ActorIP        *string         `json:"actor_ip"`
+	Action         string          `json:"action"`
+	TargetUsername string          `json:"target_username"`
+	Payload        json.RawMessage `json:"payload"`
+	OldValues      json.RawMessage `json:"old_values"`
+	NewValues      json.RawMessage `json:"new_values"`
+}
+
+// AuditFilter narrows a ListAudit query. Zero values are "no filter".
+type AuditFilter struct {
+	Username string
+	Action   string
+	Since    time.Time
+	Limit    int
+}
+
+// DefaultAuditLimit caps ListAudit results when the caller doesn't
+// specify one, so an unfiltered query can't return the whole table.
+const DefaultAuditLimit = 100
+
+// ListAudit returns audit_log rows matching filter, newest first.
+func (s *PostgresUserStore) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	query := `SELECT id, at, actor_token_id, actor_ip, action, target_username, payload, old_values, new_values FROM audit_log WHERE 1=1`
+	var args []interface{}
+
+	if filter.Username != "" {
+		args = append(args, filter.Username)
+		query += fmt.Sprintf(" AND LOWER(target_username) = LOWER($%d)", len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		query += fmt.Sprintf(" AND at >= $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("store: consultar audit_log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var actorTokenID sql.NullInt64
+		var actorIP sql.NullString
+		if err := rows.Scan(&e.ID, &e.At, &actorTokenID, &actorIP, &e.Action, &e.TargetUsername, &e.Payload, &e.OldValues, &e.NewValues); err != nil {
+			return nil, fmt.Errorf("store: ler linha de audit_log: %v", err)
+		}
+		if actorTokenID.Valid {
+			e.ActorTokenID = &actorTokenID.Int64
+		}
+		if actorIP.Valid {
+			e.ActorIP = &actorIP.String
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// redactedFields are never stored verbatim in audit_log.payload.
+var redactedFields = map[string]bool{
+	"password":     true,
+	"new_password": true,
+}
+
+func redactPayload(payload map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if redactedFields[k] {
+			redacted[k] = "[REDACTED]"
+			continue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// fetchUserJSON returns the row for username as a JSON object, omitting
+// the password column, or nil if there is no such user. It is used to
+// capture before/after snapshots for the audit trail.
+func fetchUserJSON(ctx context.Context, tx *sql.Tx, username string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	err := tx.QueryRowContext(ctx, `
+		SELECT row_to_json(u) FROM (
+			SELECT id, username, is_blocked, renewal_date, ip, indicacao, force_password_reset
+			FROM users WHERE LOWER(username) = LOWER($1)
+		) u
+	`, username).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return raw, err
+}
+
+func insertAuditLog(ctx context.Context, tx *sql.Tx, actor Actor, action, username string, payload map[string]interface{}, oldValues, newValues json.RawMessage) error {
+	payloadJSON, err := json.Marshal(redactPayload(payload))
+	if err != nil {
+		return fmt.Errorf("codificar payload: %v", err)
+	}
+
+	var tokenID sql.NullInt64
+	if actor.TokenID != 0 {
+		tokenID = sql.NullInt64{Int64: actor.TokenID, Valid: true}
+	}
+	var actorIP sql.NullString
+	if actor.IP != "" {
+		actorIP = sql.NullString{String: actor.IP, Valid: true}
+	}
+
+	// A missing snapshot (e.g. no row before an insert) is stored as the
+	// JSON literal null rather than SQL NULL, so ListAudit can always scan
+	// these columns into json.RawMessage.
+	if oldValues == nil {
+		oldValues = json.RawMessage("null")
+	}
+	if newValues == nil {
+		newValues = json.RawMessage("null")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (at, actor_token_id, actor_ip, action, target_username, payload, old_values, new_values)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, time.Now(), tokenID, actorIP, action, username, payloadJSON, oldValues, newValues)
+	return err
+}
@@ -0,0 +1,243 @@
+// Package store implements persistence for allcrypto-api's domain
+// types behind the UserStore interface, so handlers can be tested
+// against a fake without a real Postgres instance.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/userpassword"
+)
+
+// ErrNotFound is returned by mutating methods when no row matched the
+// given username.
+var ErrNotFound = errors.New("store: usuário não encontrado")
+
+// User is the persisted representation of an API user.
+type User struct {
+	ID                 int64   `json:"id"`
+	Username           string  `json:"username"`
+	Password           string  `json:"-"`
+	IsBlocked          bool    `json:"is_blocked"`
+	RenewalDate        string  `json:"renewal_date"`
+	IP                 *string `json:"ip"`
+	Indicacao          int     `json:"indicacao"`
+	ForcePasswordReset bool    `json:"force_password_reset"`
+}
+
+// Actor identifies who is performing a mutation, for the audit trail.
+type Actor struct {
+	TokenID int64
+	IP      string
+}
+
+// UserStore is the persistence boundary for User records. It is
+// implemented by PostgresUserStore for production use and may be faked
+// in tests. Every mutating method records an audit_log entry in the
+// same transaction as the change; payload is the raw request body that
+// triggered the mutation, redacted before storage.
+type UserStore interface {
+	List(ctx context.Context) ([]User, error)
+	Insert(ctx context.Context, actor Actor, payload map[string]interface{}, username, password, renewalDate string) error
+	UpdatePassword(ctx context.Context, actor Actor, payload map[string]interface{}, username, newPassword string) error
+	SetBlocked(ctx context.Context, actor Actor, payload map[string]interface{}, username string, blocked bool) error
+	UpdateRenewalDate(ctx context.Context, actor Actor, payload map[string]interface{}, username, renewalDate string) error
+	UpdateIndicacao(ctx context.Context, actor Actor, payload map[string]interface{}, username string, indicacao int) error
+	UpdateIP(ctx context.Context, actor Actor, payload map[string]interface{}, username, ip string) error
+	ResetPassword(ctx context.Context, actor Actor, payload map[string]interface{}, username string) error
+	VerifyPassword(ctx context.Context, username, password string) (bool, error)
+	ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+}
+
+// PostgresUserStore is the Postgres-backed UserStore used in
+// production.
+type PostgresUserStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUserStore wraps an already-connected *sql.DB.
+func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+	return &PostgresUserStore{db: db}
+}
+
+func (s *PostgresUserStore) List(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, username, is_blocked, renewal_date, ip, indicacao, force_password_reset FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("store: buscar usuários: %v", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.IsBlocked, &u.RenewalDate, &u.IP, &u.Indicacao, &u.ForcePasswordReset); err != nil {
+			return nil, fmt.Errorf("store: ler dados do usuário: %v", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *PostgresUserStore) Insert(ctx context.Context, actor Actor, payload map[string]interface{}, username, password, renewalDate string) error {
+	hashed, err := userpassword.Hash(password)
+	if err != nil {
+		return fmt.Errorf("store: hash de senha: %v", err)
+	}
+	return s.auditedMutation(ctx, actor, "inserirUsuario", username, payload, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO users (username, password, is_blocked, renewal_date, indicacao) VALUES ($1, $2, false, $3, 0)`,
+			username, hashed, renewalDate,
+		)
+		if err != nil {
+			return fmt.Errorf("store: inserir usuário: %v", err)
+		}
+		return nil
+	})
+}
+
+func (s *PostgresUserStore) UpdatePassword(ctx context.Context, actor Actor, payload map[string]interface{}, username, newPassword string) error {
+	hashed, err := userpassword.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("store: hash de senha: %v", err)
+	}
+	return s.auditedUpdate(ctx, actor, "atualizarSenha", username, payload,
+		`UPDATE users SET password = $1, force_password_reset = false WHERE LOWER(username) = LOWER($2)`,
+		hashed, username,
+	)
+}
+
+// ResetPassword flags a user so they must change their password on
+// their next successful login.
+func (s *PostgresUserStore) ResetPassword(ctx context.Context, actor Actor, payload map[string]interface{}, username string) error {
+	return s.auditedUpdate(ctx, actor, "resetarSenha", username, payload,
+		`UPDATE users SET force_password_reset = true WHERE LOWER(username) = LOWER($1)`, username,
+	)
+}
+
+// VerifyPassword checks password against the stored hash for username.
+// If the stored value predates argon2id (plain text), a successful
+// verification transparently re-hashes it in place so the migration
+// happens on next successful bind rather than as a batch job. This is
+// not a user-action mutation, so it is not audited.
+func (s *PostgresUserStore) VerifyPassword(ctx context.Context, username, password string) (bool, error) {
+	var id int64
+	var stored string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, password FROM users WHERE LOWER(username) = LOWER($1)`, username,
+	).Scan(&id, &stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("store: buscar senha: %v", err)
+	}
+
+	if !userpassword.IsHashed(stored) {
+		if password != stored {
+			return false, nil
+		}
+		if err := s.upgradeLegacyPassword(ctx, id, password); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	ok, err := userpassword.Verify(stored, password)
+	if err != nil {
+		return false, fmt.Errorf("store: verificar senha: %v", err)
+	}
+	return ok, nil
+}
+
+// upgradeLegacyPassword re-hashes a plaintext password column in place.
+func (s *PostgresUserStore) upgradeLegacyPassword(ctx context.Context, id int64, password string) error {
+	hashed, err := userpassword.Hash(password)
+	if err != nil {
+		return fmt.Errorf("store: hash de senha: %v", err)
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE users SET password = $1 WHERE id = $2`, hashed, id)
+	if err != nil {
+		return fmt.Errorf("store: migrar senha legada: %v", err)
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) SetBlocked(ctx context.Context, actor Actor, payload map[string]interface{}, username string, blocked bool) error {
+	return s.auditedUpdate(ctx, actor, "bloquearUsuario", username, payload,
+		`UPDATE users SET is_blocked = $1 WHERE LOWER(username) = LOWER($2)`, blocked, username,
+	)
+}
+
+func (s *PostgresUserStore) UpdateRenewalDate(ctx context.Context, actor Actor, payload map[string]interface{}, username, renewalDate string) error {
+	return s.auditedUpdate(ctx, actor, "atualizarRenovacao", username, payload,
+		`UPDATE users SET renewal_date = $1 WHERE LOWER(username) = LOWER($2)`, renewalDate, username,
+	)
+}
+
+func (s *PostgresUserStore) UpdateIndicacao(ctx context.Context, actor Actor, payload map[string]interface{}, username string, indicacao int) error {
+	return s.auditedUpdate(ctx, actor, "atualizarIndicacao", username, payload,
+		`UPDATE users SET indicacao = $1 WHERE LOWER(username) = LOWER($2)`, indicacao, username,
+	)
+}
+
+func (s *PostgresUserStore) UpdateIP(ctx context.Context, actor Actor, payload map[string]interface{}, username, ip string) error {
+	return s.auditedUpdate(ctx, actor, "atualizarIP", username, payload,
+		`UPDATE users SET ip = $1 WHERE LOWER(username) = LOWER($2)`, ip, username,
+	)
+}
+
+// auditedUpdate runs a single-parameterized UPDATE against users inside
+// a transaction that also records an audit_log entry, mapping a
+// zero-rows-affected result to ErrNotFound.
+func (s *PostgresUserStore) auditedUpdate(ctx context.Context, actor Actor, action, username string, payload map[string]interface{}, query string, args ...interface{}) error {
+	return s.auditedMutation(ctx, actor, action, username, payload, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("store: executar atualização: %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("store: ler linhas afetadas: %v", err)
+		}
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// auditedMutation runs mutate inside a transaction, capturing the
+// user's row before and after the mutation and recording both, along
+// with the redacted request payload, as an audit_log entry. The whole
+// thing commits atomically: a failed audit insert rolls back the
+// mutation too.
+func (s *PostgresUserStore) auditedMutation(ctx context.Context, actor Actor, action, username string, payload map[string]interface{}, mutate func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	oldValues, err := fetchUserJSON(ctx, tx, username)
+	if err != nil {
+		return fmt.Errorf("store: ler estado anterior: %v", err)
+	}
+
+	if err := mutate(tx); err != nil {
+		return err
+	}
+
+	newValues, err := fetchUserJSON(ctx, tx, username)
+	if err != nil {
+		return fmt.Errorf("store: ler estado posterior: %v", err)
+	}
+
+	if err := insertAuditLog(ctx, tx, actor, action, username, payload, oldValues, newValues); err != nil {
+		return fmt.Errorf("store: gravar audit_log: %v", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/Sharkprojects/allcrypto-api/pkg/userpassword"
+)
+
+// newTestDB spins up a throwaway Postgres container and returns a
+// connection with the users table already created. It skips the test
+// when Docker isn't reachable, which keeps `go test ./...` usable in
+// environments without a container runtime.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("SKIP_DOCKER_TESTS") != "" {
+		t.Skip("SKIP_DOCKER_TESTS set, skipping testcontainers-backed test")
+	}
+
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "allcrypto_test",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Skipf("não foi possível iniciar container Postgres (Docker indisponível?): %v", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("container.Host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		t.Fatalf("container.MappedPort: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/allcrypto_test?sslmode=disable", host, port.Port())
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatalf("db.PingContext: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE users (
+			id bigserial PRIMARY KEY,
+			username text NOT NULL,
+			password text NOT NULL,
+			is_blocked boolean NOT NULL DEFAULT false,
+			renewal_date text,
+			ip text,
+			indicacao integer NOT NULL DEFAULT 0,
+			force_password_reset boolean NOT NULL DEFAULT false
+		)
+	`); err != nil {
+		t.Fatalf("criar tabela users: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE audit_log (
+			id bigserial PRIMARY KEY,
+			at timestamptz NOT NULL DEFAULT now(),
+			actor_token_id bigint,
+			actor_ip inet,
+			action text NOT NULL,
+			target_username text NOT NULL,
+			payload jsonb,
+			old_values jsonb,
+			new_values jsonb
+		)
+	`); err != nil {
+		t.Fatalf("criar tabela audit_log: %v", err)
+	}
+	return db
+}
+
+func TestPostgresUserStore(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	s := NewPostgresUserStore(db)
+	actor := Actor{TokenID: 1, IP: "127.0.0.1"}
+
+	if err := s.Insert(ctx, actor, map[string]interface{}{"action": "inserirUsuario"}, "alice", "senha123", "2026-01-01"); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"UpdatePassword", func() error {
+			return s.UpdatePassword(ctx, actor, map[string]interface{}{"new_password": "novaSenha"}, "Alice", "novaSenha")
+		}},
+		{"SetBlocked", func() error {
+			return s.SetBlocked(ctx, actor, map[string]interface{}{"is_blocked": true}, "ALICE", true)
+		}},
+		{"UpdateRenewalDate", func() error {
+			return s.UpdateRenewalDate(ctx, actor, map[string]interface{}{"renewal_date": "2027-01-01"}, "alice", "2027-01-01")
+		}},
+		{"UpdateIndicacao", func() error { return s.UpdateIndicacao(ctx, actor, map[string]interface{}{"indicacao": 3}, "alice", 3) }},
+		{"UpdateIP", func() error {
+			return s.UpdateIP(ctx, actor, map[string]interface{}{"novo_ip": "1.2.3.4"}, "alice", "1.2.3.4")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		})
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		if err := s.SetBlocked(ctx, actor, nil, "no-such-user", true); err != ErrNotFound {
+			t.Fatalf("esperava ErrNotFound, obteve %v", err)
+		}
+	})
+
+	users, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("esperava 1 usuário, obteve %d", len(users))
+	}
+	u := users[0]
+	if !u.IsBlocked || u.Indicacao != 3 || u.RenewalDate != "2027-01-01" || u.IP == nil || *u.IP != "1.2.3.4" {
+		t.Fatalf("estado inesperado após atualizações: %+v", u)
+	}
+
+	t.Run("VerifyPassword", func(t *testing.T) {
+		ok, err := s.VerifyPassword(ctx, "alice", "novaSenha")
+		if err != nil || !ok {
+			t.Fatalf("esperava senha válida, got ok=%v err=%v", ok, err)
+		}
+		ok, err = s.VerifyPassword(ctx, "alice", "senha-errada")
+		if err != nil || ok {
+			t.Fatalf("esperava senha inválida, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("VerifyPasswordUpgradesLegacyPlaintext", func(t *testing.T) {
+		if err := s.Insert(ctx, actor, map[string]interface{}{"action": "inserirUsuario"}, "bob", "", "2026-01-01"); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		if _, err := db.ExecContext(ctx, `UPDATE users SET password = 'senha-em-texto-puro' WHERE username = 'bob'`); err != nil {
+			t.Fatalf("preparar senha legada: %v", err)
+		}
+
+		ok, err := s.VerifyPassword(ctx, "bob", "senha-em-texto-puro")
+		if err != nil || !ok {
+			t.Fatalf("esperava senha legada válida, got ok=%v err=%v", ok, err)
+		}
+
+		var stored string
+		if err := db.QueryRowContext(ctx, `SELECT password FROM users WHERE username = 'bob'`).Scan(&stored); err != nil {
+			t.Fatalf("ler senha migrada: %v", err)
+		}
+		if !userpassword.IsHashed(stored) {
+			t.Fatalf("esperava senha migrada para argon2id, got %q", stored)
+		}
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		if err := s.ResetPassword(ctx, actor, map[string]interface{}{"action": "resetarSenha"}, "alice"); err != nil {
+			t.Fatalf("ResetPassword: %v", err)
+		}
+		users, err := s.List(ctx)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		for _, u := range users {
+			if u.Username == "alice" && !u.ForcePasswordReset {
+				t.Fatalf("esperava force_password_reset=true para alice")
+			}
+		}
+	})
+
+	t.Run("ListAudit", func(t *testing.T) {
+		entries, err := s.ListAudit(ctx, AuditFilter{Username: "alice"})
+		if err != nil {
+			t.Fatalf("ListAudit: %v", err)
+		}
+		if len(entries) == 0 {
+			t.Fatalf("esperava ao menos uma entrada de audit_log para alice")
+		}
+		for _, e := range entries {
+			if strings.Contains(string(e.Payload), "novaSenha") || strings.Contains(string(e.Payload), "senha123") {
+				t.Fatalf("payload não deveria conter senha em texto puro: %s", e.Payload)
+			}
+		}
+	})
+}
@@ -0,0 +1,92 @@
+// Package userpassword hashes and verifies user passwords with
+// argon2id, encoding them as standard PHC strings
+// ($argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>).
+package userpassword
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Params are the argon2id cost parameters used for new hashes. Existing
+// hashes embed their own params, so changing these only affects
+// passwords hashed from now on.
+var Params = struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}{
+	Time:    1,
+	Memory:  64 * 1024,
+	Threads: 4,
+	KeyLen:  32,
+	SaltLen: 16,
+}
+
+const phcPrefix = "$argon2id$"
+
+// IsHashed reports whether a stored password value is already an
+// argon2id PHC string, as opposed to a legacy plaintext value.
+func IsHashed(stored string) bool {
+	return strings.HasPrefix(stored, phcPrefix)
+}
+
+// Hash derives an argon2id hash for password with a fresh random salt
+// and encodes it as a PHC string.
+func Hash(password string) (string, error) {
+	salt := make([]byte, Params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("userpassword: gerar salt: %v", err)
+	}
+	hash := argon2.IDKey([]byte(password), salt, Params.Time, Params.Memory, Params.Threads, Params.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, Params.Memory, Params.Time, Params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// Verify reports whether password matches an argon2id PHC string
+// previously produced by Hash.
+func Verify(encoded, password string) (bool, error) {
+	var version int
+	var memory, time uint32
+	var threads uint8
+	var saltB64, hashB64 string
+
+	_, err := fmt.Sscanf(encoded, "$argon2id$v=%d$m=%d,t=%d,p=%d$%s", &version, &memory, &time, &threads, &saltB64)
+	if err != nil {
+		return false, fmt.Errorf("userpassword: formato de hash inválido: %v", err)
+	}
+	// Sscanf with %s greedily consumed the remaining "<salt>$<hash>" into
+	// saltB64; split it back apart.
+	parts := strings.SplitN(saltB64, "$", 2)
+	if len(parts) != 2 {
+		return false, fmt.Errorf("userpassword: formato de hash inválido")
+	}
+	saltB64, hashB64 = parts[0], parts[1]
+
+	if version != argon2.Version {
+		return false, fmt.Errorf("userpassword: versão argon2 incompatível: %d", version)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(saltB64)
+	if err != nil {
+		return false, fmt.Errorf("userpassword: decodificar salt: %v", err)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(hashB64)
+	if err != nil {
+		return false, fmt.Errorf("userpassword: decodificar hash: %v", err)
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
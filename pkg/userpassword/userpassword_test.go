@@ -0,0 +1,29 @@
+package userpassword
+
+import "testing"
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !IsHashed(hash) {
+		t.Fatalf("esperava prefixo $argon2id$, got %q", hash)
+	}
+
+	ok, err := Verify(hash, "correct horse battery staple")
+	if err != nil || !ok {
+		t.Fatalf("esperava senha correta válida, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = Verify(hash, "wrong password")
+	if err != nil || ok {
+		t.Fatalf("esperava senha incorreta inválida, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestIsHashedRejectsLegacyPlaintext(t *testing.T) {
+	if IsHashed("hunter2") {
+		t.Fatalf("senha em texto puro não deveria parecer um hash argon2id")
+	}
+}